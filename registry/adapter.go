@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status is the result of a Remote's HealthCheck.
+type Status struct {
+	Healthy bool
+	Message string
+}
+
+// AdapterFactory creates Remotes for registry hosts it recognises, such as
+// a particular cloud provider's registry service. Adapters are registered
+// with RegisterAdapter -- typically from an adapter package's init() --
+// and are tried, in registration order, by the RemoteClientFactory
+// returned from NewAdapterClientFactory.
+type AdapterFactory interface {
+	// Matches reports whether this adapter handles the given registry
+	// host, e.g. "123456789012.dkr.ecr.us-east-1.amazonaws.com".
+	Matches(host string) bool
+	// New creates a Remote for host. Only called after Matches(host) has
+	// returned true.
+	New(host string) (Remote, error)
+}
+
+var (
+	adaptersMu   sync.Mutex
+	adapters     = map[string]AdapterFactory{}
+	adapterOrder []string
+)
+
+// RegisterAdapter registers a named AdapterFactory. It panics if name is
+// already registered -- adapters are expected to register themselves
+// exactly once, from init(), the same way e.g. database/sql drivers do.
+func RegisterAdapter(name string, factory AdapterFactory) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	if _, exists := adapters[name]; exists {
+		panic(fmt.Sprintf("registry: RegisterAdapter called twice for %q", name))
+	}
+	adapters[name] = factory
+	adapterOrder = append(adapterOrder, name)
+}
+
+// adapterClientFactory dispatches CreateFor to whichever registered adapter
+// matches the host, falling back to a generic client for anything that
+// doesn't need special handling (Docker Hub, a self-hosted registry, and
+// so on).
+type adapterClientFactory struct {
+	fallback RemoteClientFactory
+}
+
+// NewAdapterClientFactory returns a RemoteClientFactory that tries every
+// adapter registered via RegisterAdapter, in registration order, and falls
+// back to fallback if none match the host. This is the extension point
+// cloud-registry adapters (ECR, GCR, ACR, Quay, ...) hang off, so that
+// supporting a new registry doesn't require forking this package.
+func NewAdapterClientFactory(fallback RemoteClientFactory) RemoteClientFactory {
+	return &adapterClientFactory{fallback: fallback}
+}
+
+func (f *adapterClientFactory) CreateFor(host string) (Remote, error) {
+	adaptersMu.Lock()
+	order := append([]string(nil), adapterOrder...)
+	adaptersMu.Unlock()
+
+	for _, name := range order {
+		adaptersMu.Lock()
+		a := adapters[name]
+		adaptersMu.Unlock()
+		if a.Matches(host) {
+			return a.New(host)
+		}
+	}
+	return f.fallback.CreateFor(host)
+}
+
+// RefreshingRemote wraps a Remote built from short-lived credentials,
+// rebuilding it via build whenever the last build is older than ttl.
+// Cloud adapters whose tokens expire (ECR, GCR, ACR) use this instead of
+// each reimplementing their own refresh-on-expiry bookkeeping.
+type RefreshingRemote struct {
+	mu      sync.Mutex
+	build   func() (Remote, error)
+	ttl     time.Duration
+	current Remote
+	builtAt time.Time
+}
+
+// NewRefreshingRemote returns a Remote that lazily calls build to obtain an
+// inner Remote, and calls it again once ttl has passed since the last call.
+func NewRefreshingRemote(ttl time.Duration, build func() (Remote, error)) *RefreshingRemote {
+	return &RefreshingRemote{build: build, ttl: ttl}
+}
+
+func (r *RefreshingRemote) inner() (Remote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil || time.Since(r.builtAt) > r.ttl {
+		rem, err := r.build()
+		if err != nil {
+			return nil, err
+		}
+		r.current = rem
+		r.builtAt = time.Now()
+	}
+	return r.current, nil
+}
+
+func (r *RefreshingRemote) Tags(ctx context.Context, repository Repository) ([]string, error) {
+	rem, err := r.inner()
+	if err != nil {
+		return nil, err
+	}
+	return rem.Tags(ctx, repository)
+}
+
+func (r *RefreshingRemote) Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	rem, err := r.inner()
+	if err != nil {
+		return nil, err
+	}
+	return rem.Manifest(ctx, repository, tag)
+}
+
+func (r *RefreshingRemote) HealthCheck() (Status, error) {
+	rem, err := r.inner()
+	if err != nil {
+		return Status{}, err
+	}
+	return rem.HealthCheck()
+}
+
+func (r *RefreshingRemote) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != nil {
+		r.current.Cancel()
+	}
+}
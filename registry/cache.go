@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CacheEntry is what a Cache stores against a key: the raw (JSON-encoded)
+// value, ETag/Last-Modified for conditional revalidation, and an
+// expiry. The zero Expires means the entry never goes stale on its own --
+// used for manifests cached by digest, which are immutable by definition.
+type CacheEntry struct {
+	Value        []byte
+	ETag         string
+	LastModified string
+	Expires      time.Time
+}
+
+// stale reports whether the entry's TTL has passed. A zero Expires is
+// never stale.
+func (e CacheEntry) stale() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+// Cache is the storage backend NewCachingRemote uses to persist cached tag
+// lists and manifests. It's deliberately minimal -- just enough for
+// NewCachingRemote to implement TTLs and conditional revalidation itself --
+// so that swapping backends doesn't change caching behaviour.
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+// memoryCache is a simple, process-local Cache backed by a mutex-guarded
+// map. It's the default for a single flux instance.
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache returns a Cache that holds entries in memory for the
+// lifetime of the process.
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: map[string]CacheEntry{}}
+}
+
+func (c *memoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+func (c *memoryCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// GroupCache is the subset of a peer-aware group cache's API NewGroupCache
+// needs. github.com/mailgun/groupcache/v2's *Group satisfies this
+// directly; the original golang/groupcache does not, since it's
+// pull-through only and has no public Set -- it would need a GetterFunc
+// closure over a Cache of its own instead.
+type GroupCache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, expire time.Time) error
+}
+
+type groupCacheAdapter struct {
+	group GroupCache
+}
+
+// NewGroupCache adapts a GroupCache (e.g. a mailgun/groupcache/v2 Group)
+// into a Cache, so several flux instances can share one cache of tags and
+// manifests instead of each polling the registry independently.
+func NewGroupCache(group GroupCache) Cache {
+	return &groupCacheAdapter{group: group}
+}
+
+func (a *groupCacheAdapter) Get(key string) (CacheEntry, bool) {
+	raw, ok, err := a.group.Get(context.Background(), key)
+	if err != nil || !ok {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (a *groupCacheAdapter) Set(key string, entry CacheEntry) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = a.group.Set(context.Background(), key, raw, entry.Expires)
+}
+
+// CacheResult classifies the outcome of a single cache lookup, for
+// CacheMetrics.
+type CacheResult int
+
+const (
+	CacheMiss CacheResult = iota
+	CacheHit
+	CacheRevalidated
+)
+
+func (r CacheResult) String() string {
+	switch r {
+	case CacheHit:
+		return "hit"
+	case CacheRevalidated:
+		return "revalidated"
+	default:
+		return "miss"
+	}
+}
+
+// CacheMetrics records cache effectiveness, so operators can see whether
+// NewCachingRemote is actually saving requests. kind is "tags" or
+// "manifest".
+type CacheMetrics interface {
+	ObserveCacheResult(kind string, result CacheResult)
+}
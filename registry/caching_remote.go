@@ -0,0 +1,234 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultTagsTTL is how long a repository's tag list (and, by extension, a
+// tag's manifest) is trusted before being revalidated against the
+// registry, used when CacheOptions.TagsTTL is left at its zero value.
+const defaultTagsTTL = time.Minute
+
+// CacheOptions configures NewCachingRemote.
+type CacheOptions struct {
+	// TagsTTL bounds how long a repository's tag list, and a given tag's
+	// resolved manifest, are served from cache before being revalidated.
+	// Manifests looked up by digest are exempt from this -- they're
+	// immutable, so once cached they're kept indefinitely.
+	TagsTTL time.Duration
+	// Metrics, if set, is notified of every cache lookup's outcome.
+	Metrics CacheMetrics
+}
+
+// ConditionalTags is implemented by Remotes that can revalidate a
+// previously-fetched tag list with a conditional request, so
+// NewCachingRemote doesn't have to re-download the full list just to learn
+// it hasn't changed.
+type ConditionalTags interface {
+	TagsIfNoneMatch(ctx context.Context, repository Repository, etag string) (tags []string, newETag string, notModified bool, err error)
+}
+
+// ConditionalManifest is the equivalent of ConditionalTags for a single
+// tag's manifest.
+type ConditionalManifest interface {
+	ManifestIfNoneMatch(ctx context.Context, repository Repository, tag, etag string) (images []Image, newETag string, notModified bool, err error)
+}
+
+type cachingRemote struct {
+	inner Remote
+	cache Cache
+	opts  CacheOptions
+}
+
+// NewCachingRemote wraps inner with a read-through cache of Tags and
+// Manifest results, keyed by canonical reference. It's a peer to
+// NewInstrumentedRemote: both wrap a Remote without changing what it
+// means, so the two can be composed in either order.
+func NewCachingRemote(inner Remote, cache Cache, opts CacheOptions) Remote {
+	if opts.TagsTTL <= 0 {
+		opts.TagsTTL = defaultTagsTTL
+	}
+	return &cachingRemote{inner: inner, cache: cache, opts: opts}
+}
+
+func (r *cachingRemote) observe(kind string, result CacheResult) {
+	if r.opts.Metrics != nil {
+		r.opts.Metrics.ObserveCacheResult(kind, result)
+	}
+}
+
+func tagsCacheKey(repository Repository) string {
+	return "tags:" + repository.NormalizedName()
+}
+
+func manifestCacheKey(repository Repository, tag string) string {
+	return "manifest:" + repository.NormalizedName() + ":" + tag
+}
+
+func digestCacheKey(repository Repository, digest string) string {
+	return "digest:" + repository.NormalizedName() + "@" + digest
+}
+
+// isDigest reports whether tag is actually a digest (e.g.
+// "sha256:e3b0c4..."), as GetImageByDigest passes, rather than a mutable
+// tag name -- tag names can't contain ":", so this is unambiguous.
+func isDigest(tag string) bool {
+	return strings.Contains(tag, ":")
+}
+
+func (r *cachingRemote) Tags(ctx context.Context, repository Repository) ([]string, error) {
+	key := tagsCacheKey(repository)
+	entry, found := r.cache.Get(key)
+
+	if found && !entry.stale() {
+		var tags []string
+		if err := json.Unmarshal(entry.Value, &tags); err == nil {
+			r.observe("tags", CacheHit)
+			return tags, nil
+		}
+	}
+
+	if found {
+		if cr, ok := r.inner.(ConditionalTags); ok {
+			tags, etag, notModified, err := cr.TagsIfNoneMatch(ctx, repository, entry.ETag)
+			if err == nil && notModified {
+				r.observe("tags", CacheRevalidated)
+				r.storeTags(key, entry.Value, entry.ETag)
+				var cached []string
+				_ = json.Unmarshal(entry.Value, &cached)
+				return cached, nil
+			}
+			if err == nil {
+				r.observe("tags", CacheMiss)
+				return tags, r.storeTagsValue(key, tags, etag)
+			}
+			// conditional request itself failed: fall through to a plain fetch
+		}
+	}
+
+	r.observe("tags", CacheMiss)
+	tags, err := r.inner.Tags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	return tags, r.storeTagsValue(key, tags, "")
+}
+
+func (r *cachingRemote) storeTagsValue(key string, tags []string, etag string) error {
+	raw, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("registry: encoding tags for cache: %w", err)
+	}
+	r.storeTags(key, raw, etag)
+	return nil
+}
+
+func (r *cachingRemote) storeTags(key string, raw []byte, etag string) {
+	r.cache.Set(key, CacheEntry{
+		Value:   raw,
+		ETag:    etag,
+		Expires: time.Now().Add(r.opts.TagsTTL),
+	})
+}
+
+// Manifest resolves tag (which may itself be a digest, as GetImageByDigest
+// passes) through the appropriate cache keyspace: a digest is immutable,
+// so it's cached indefinitely and never revalidated, while an ordinary tag
+// can move and so is only trusted for TagsTTL.
+func (r *cachingRemote) Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	if isDigest(tag) {
+		return r.manifestByDigest(ctx, repository, tag)
+	}
+	return r.manifestByTag(ctx, repository, tag)
+}
+
+func (r *cachingRemote) manifestByDigest(ctx context.Context, repository Repository, digest string) ([]Image, error) {
+	key := digestCacheKey(repository, digest)
+	if entry, found := r.cache.Get(key); found {
+		var images []Image
+		if err := json.Unmarshal(entry.Value, &images); err == nil {
+			r.observe("manifest", CacheHit)
+			return images, nil
+		}
+	}
+
+	r.observe("manifest", CacheMiss)
+	images, err := r.inner.Manifest(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(images)
+	if err != nil {
+		return images, fmt.Errorf("registry: encoding manifest for cache: %w", err)
+	}
+	// Expires is left zero: a digest never goes stale.
+	r.cache.Set(key, CacheEntry{Value: raw})
+	return images, nil
+}
+
+func (r *cachingRemote) manifestByTag(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	key := manifestCacheKey(repository, tag)
+	entry, found := r.cache.Get(key)
+
+	if found && !entry.stale() {
+		var images []Image
+		if err := json.Unmarshal(entry.Value, &images); err == nil {
+			r.observe("manifest", CacheHit)
+			return images, nil
+		}
+	}
+
+	if found {
+		if cr, ok := r.inner.(ConditionalManifest); ok {
+			images, etag, notModified, err := cr.ManifestIfNoneMatch(ctx, repository, tag, entry.ETag)
+			if err == nil && notModified {
+				r.observe("manifest", CacheRevalidated)
+				r.storeManifestTag(key, entry.Value, entry.ETag)
+				var cached []Image
+				_ = json.Unmarshal(entry.Value, &cached)
+				return cached, nil
+			}
+			if err == nil {
+				r.observe("manifest", CacheMiss)
+				return images, r.storeManifestTagValue(key, images, etag)
+			}
+			// conditional request itself failed: fall through to a plain fetch
+		}
+	}
+
+	r.observe("manifest", CacheMiss)
+	images, err := r.inner.Manifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+	return images, r.storeManifestTagValue(key, images, "")
+}
+
+func (r *cachingRemote) storeManifestTagValue(key string, images []Image, etag string) error {
+	raw, err := json.Marshal(images)
+	if err != nil {
+		return fmt.Errorf("registry: encoding manifest for cache: %w", err)
+	}
+	r.storeManifestTag(key, raw, etag)
+	return nil
+}
+
+func (r *cachingRemote) storeManifestTag(key string, raw []byte, etag string) {
+	r.cache.Set(key, CacheEntry{
+		Value:   raw,
+		ETag:    etag,
+		Expires: time.Now().Add(r.opts.TagsTTL),
+	})
+}
+
+func (r *cachingRemote) HealthCheck() (Status, error) {
+	return r.inner.HealthCheck()
+}
+
+func (r *cachingRemote) Cancel() {
+	r.inner.Cancel()
+}
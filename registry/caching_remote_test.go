@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// cacheTestRemote is a fake Remote (plus ConditionalTags/ConditionalManifest)
+// for exercising cachingRemote's hit/stale/revalidate/miss branches.
+type cacheTestRemote struct {
+	tags        []string
+	images      map[string][]Image
+	etag        string
+	notModified bool
+
+	tagsCalls     int
+	manifestCalls int
+}
+
+func (r *cacheTestRemote) Tags(ctx context.Context, repository Repository) ([]string, error) {
+	r.tagsCalls++
+	return r.tags, nil
+}
+
+func (r *cacheTestRemote) TagsIfNoneMatch(ctx context.Context, repository Repository, etag string) ([]string, string, bool, error) {
+	r.tagsCalls++
+	if r.notModified {
+		return nil, etag, true, nil
+	}
+	return r.tags, r.etag, false, nil
+}
+
+func (r *cacheTestRemote) Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	r.manifestCalls++
+	return r.images[tag], nil
+}
+
+func (r *cacheTestRemote) ManifestIfNoneMatch(ctx context.Context, repository Repository, tag, etag string) ([]Image, string, bool, error) {
+	r.manifestCalls++
+	if r.notModified {
+		return nil, etag, true, nil
+	}
+	return r.images[tag], r.etag, false, nil
+}
+
+func (r *cacheTestRemote) HealthCheck() (Status, error) { return Status{Healthy: true}, nil }
+func (r *cacheTestRemote) Cancel()                      {}
+
+// testRepository returns a Repository suitable for exercising cachingRemote
+// -- the zero value has an empty NormalizedName, so its cache keys would name
+// no repository; every test here goes through ParseRepository like real
+// callers do.
+func testRepository(t *testing.T) Repository {
+	t.Helper()
+	repo, err := ParseRepository("example.com/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return repo
+}
+
+func TestCachingRemote_TagsHit(t *testing.T) {
+	repo := testRepository(t)
+	inner := &cacheTestRemote{tags: []string{"a", "b"}}
+	rem := NewCachingRemote(inner, NewMemoryCache(), CacheOptions{TagsTTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, err := rem.Tags(context.Background(), repo); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if inner.tagsCalls != 1 {
+		t.Fatalf("expected the second Tags() to be served from cache, inner was called %d times", inner.tagsCalls)
+	}
+}
+
+func TestCachingRemote_TagsStaleRevalidates(t *testing.T) {
+	repo := testRepository(t)
+	inner := &cacheTestRemote{tags: []string{"a"}, etag: "v1", notModified: true}
+	rem := NewCachingRemote(inner, NewMemoryCache(), CacheOptions{TagsTTL: time.Millisecond})
+
+	if _, err := rem.Tags(context.Background(), repo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	tags, err := rem.Tags(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.tagsCalls != 2 {
+		t.Fatalf("expected a conditional revalidation call, inner was called %d times", inner.tagsCalls)
+	}
+	if len(tags) != 1 || tags[0] != "a" {
+		t.Fatalf("expected the cached tags back from a 304, got %v", tags)
+	}
+}
+
+func TestCachingRemote_TagsStaleMissRefetches(t *testing.T) {
+	repo := testRepository(t)
+	inner := &cacheTestRemote{tags: []string{"a"}, etag: "v1", notModified: false}
+	rem := NewCachingRemote(inner, NewMemoryCache(), CacheOptions{TagsTTL: time.Millisecond})
+
+	if _, err := rem.Tags(context.Background(), repo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	inner.tags = []string{"a", "b"}
+
+	tags, err := rem.Tags(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("expected the refreshed tag list, got %v", tags)
+	}
+}
+
+func TestCachingRemote_ManifestByDigestIsCachedIndefinitely(t *testing.T) {
+	repo := testRepository(t)
+	const digest = "sha256:abcd"
+	inner := &cacheTestRemote{images: map[string][]Image{digest: {{Digest: digest}}}}
+	// A TTL this short would make a tag-keyed entry stale immediately; a
+	// digest-keyed one should be unaffected.
+	rem := NewCachingRemote(inner, NewMemoryCache(), CacheOptions{TagsTTL: time.Nanosecond})
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if _, err := rem.Manifest(context.Background(), repo, digest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if inner.manifestCalls != 1 {
+		t.Fatalf("expected digest lookups to be cached indefinitely, inner was called %d times", inner.manifestCalls)
+	}
+}
+
+func TestCachingRemote_ManifestByTagRespectsTTL(t *testing.T) {
+	repo := testRepository(t)
+	inner := &cacheTestRemote{images: map[string][]Image{"latest": {{Tag: "latest"}}}}
+	rem := NewCachingRemote(inner, NewMemoryCache(), CacheOptions{TagsTTL: time.Nanosecond})
+
+	if _, err := rem.Manifest(context.Background(), repo, "latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := rem.Manifest(context.Background(), repo, "latest"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.manifestCalls != 2 {
+		t.Fatalf("expected the stale tag entry to trigger a second fetch, inner was called %d times", inner.manifestCalls)
+	}
+}
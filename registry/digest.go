@@ -0,0 +1,10 @@
+package registry
+
+import digest "github.com/opencontainers/go-digest"
+
+// parseDigest validates and parses a digest string such as
+// "sha256:e3b0c44...". It is a thin wrapper so the rest of the package only
+// depends on the go-digest type indirectly, through reference.Canonical.
+func parseDigest(s string) (digest.Digest, error) {
+	return digest.Parse(s)
+}
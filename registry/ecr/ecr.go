@@ -0,0 +1,83 @@
+// Package ecr is a registry adapter for Amazon Elastic Container Registry.
+// Importing it for its side effect registers the adapter with the registry
+// package; nothing else needs to reference it directly.
+package ecr
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+
+	"github.com/firehalt/flux/registry"
+)
+
+// tokenTTL is conservative: ECR authorization tokens are valid for 12
+// hours, but we'd rather refresh a little early than fail a pull because a
+// token expired mid-flight.
+const tokenTTL = 10 * time.Hour
+
+var hostPattern = regexp.MustCompile(`^\d+\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com$`)
+
+func init() {
+	registry.RegisterAdapter("ecr", factory{})
+}
+
+type factory struct{}
+
+func (factory) Matches(host string) bool {
+	return hostPattern.MatchString(host)
+}
+
+func (factory) New(host string) (registry.Remote, error) {
+	region, err := regionFromHost(host)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewRefreshingRemote(tokenTTL, func() (registry.Remote, error) {
+		username, password, err := authorizationToken(region)
+		if err != nil {
+			return nil, fmt.Errorf("ecr: refreshing authorization token: %w", err)
+		}
+		return registry.NewHTTPRemote(host, username, password)
+	}), nil
+}
+
+func regionFromHost(host string) (string, error) {
+	m := hostPattern.FindStringSubmatch(host)
+	if m == nil {
+		return "", fmt.Errorf("ecr: %q is not an ECR host", host)
+	}
+	return m[1], nil
+}
+
+// authorizationToken exchanges the ambient AWS credentials (instance role,
+// env vars, shared config, ...) for a short-lived Docker basic-auth
+// username/password pair, via ECR's GetAuthorizationToken API.
+func authorizationToken(region string) (username, password string, err error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return "", "", err
+	}
+	out, err := ecr.New(sess).GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return "", "", err
+	}
+	if len(out.AuthorizationData) == 0 {
+		return "", "", fmt.Errorf("ecr: no authorization data returned")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(aws.StringValue(out.AuthorizationData[0].AuthorizationToken))
+	if err != nil {
+		return "", "", fmt.Errorf("ecr: decoding authorization token: %w", err)
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("ecr: malformed authorization token")
+	}
+	return parts[0], parts[1], nil
+}
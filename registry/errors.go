@@ -0,0 +1,10 @@
+package registry
+
+import "errors"
+
+// ErrPartialResults is wrapped in the error returned by GetRepository when
+// the caller opted into RegistryOptions.BestEffort and an error (or context
+// cancellation) stopped at least one manifest from being fetched. The images
+// fetched so far, possibly none, are still returned alongside it; use
+// errors.Is to detect this case and decide whether to use them.
+var ErrPartialResults = errors.New("registry: partial results, some manifests could not be fetched")
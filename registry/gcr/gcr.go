@@ -0,0 +1,62 @@
+// Package gcr is a registry adapter for Google Container Registry and
+// Artifact Registry. Importing it for its side effect registers the
+// adapter with the registry package; nothing else needs to reference it
+// directly.
+package gcr
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/firehalt/flux/registry"
+)
+
+// scope is the minimal OAuth2 scope that allows reading images.
+const scope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// tokenTTL is conservative relative to the ~1h lifetime of the access
+// tokens google.DefaultTokenSource hands out.
+const tokenTTL = 30 * time.Minute
+
+var hostPattern = regexp.MustCompile(`^(([a-z]+\.)?gcr\.io|[a-z0-9-]+-docker\.pkg\.dev)$`)
+
+func init() {
+	registry.RegisterAdapter("gcr", factory{})
+}
+
+type factory struct{}
+
+func (factory) Matches(host string) bool {
+	return hostPattern.MatchString(host)
+}
+
+func (factory) New(host string) (registry.Remote, error) {
+	return registry.NewRefreshingRemote(tokenTTL, func() (registry.Remote, error) {
+		token, err := accessToken(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("gcr: refreshing access token: %w", err)
+		}
+		// GCR and Artifact Registry both accept any username alongside an
+		// OAuth2 access token as the password, by convention "oauth2accesstoken".
+		return registry.NewHTTPRemote(host, "oauth2accesstoken", token)
+	}), nil
+}
+
+// accessToken obtains an access token from whichever credential the
+// environment provides: GOOGLE_APPLICATION_CREDENTIALS keyfile, the GCE/GKE
+// metadata server, or gcloud's own cached user credentials.
+func accessToken(ctx context.Context) (string, error) {
+	ts, err := google.DefaultTokenSource(ctx, scope)
+	if err != nil {
+		return "", fmt.Errorf("obtaining default credentials: %w", err)
+	}
+	tok, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// httpRemote is the generic Docker Registry HTTP API v2 client used for any
+// host that doesn't need cloud-specific credential handling. Adapters that
+// do (ECR, GCR, ACR, ...) still end up calling NewHTTPRemote once they've
+// turned their cloud credentials into a plain username/password pair, so
+// there's exactly one place that speaks the registry wire protocol.
+type httpRemote struct {
+	host               string
+	username, password string
+	client             *http.Client
+	// ctx is this Remote's own lifetime, ended by Cancel(). It is checked
+	// before each request starts; the request itself carries only the ctx
+	// passed into Tags/Manifest/etc., so Cancel doesn't abort a request
+	// already in flight.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewHTTPRemote creates a Remote that talks to host's Docker Registry HTTP
+// API v2 endpoints using HTTP basic auth. An empty username/password is
+// valid for registries that allow anonymous pulls.
+func NewHTTPRemote(host, username, password string) (Remote, error) {
+	if host == "" {
+		return nil, fmt.Errorf("registry: empty host")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpRemote{
+		host:     host,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: requestTimeout},
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (r *httpRemote) url(format string, args ...interface{}) string {
+	return "https://" + r.host + fmt.Sprintf(format, args...)
+}
+
+// do issues a GET, optionally conditional on ifNoneMatch. notModified is
+// only ever true when ifNoneMatch is non-empty and the server replied 304.
+// Each request still carries its own caller-supplied ctx (for per-call
+// deadlines/cancellation); r.ctx only gates whether a new request is
+// allowed to start at all, see Cancel.
+func (r *httpRemote) do(ctx context.Context, url, accept, ifNoneMatch string) (resp *http.Response, notModified bool, err error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	if r.username != "" || r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err = r.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	if ifNoneMatch != "" && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, true, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("registry: %s: unexpected status %s", url, resp.Status)
+	}
+	return resp, false, nil
+}
+
+func (r *httpRemote) Tags(ctx context.Context, repository Repository) ([]string, error) {
+	tags, _, _, err := r.tags(ctx, repository, "")
+	return tags, err
+}
+
+// TagsIfNoneMatch implements ConditionalTags.
+func (r *httpRemote) TagsIfNoneMatch(ctx context.Context, repository Repository, etag string) ([]string, string, bool, error) {
+	return r.tags(ctx, repository, etag)
+}
+
+func (r *httpRemote) tags(ctx context.Context, repository Repository, ifNoneMatch string) ([]string, string, bool, error) {
+	resp, notModified, err := r.do(ctx, r.url("/v2/%s/tags/list", repository.Path()), "", ifNoneMatch)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if notModified {
+		return nil, ifNoneMatch, true, nil
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", false, fmt.Errorf("registry: decoding tags list: %w", err)
+	}
+	return body.Tags, resp.Header.Get("ETag"), false, nil
+}
+
+func (r *httpRemote) Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	images, _, _, err := r.manifest(ctx, repository, tag, "")
+	return images, err
+}
+
+// ManifestIfNoneMatch implements ConditionalManifest.
+func (r *httpRemote) ManifestIfNoneMatch(ctx context.Context, repository Repository, tag, etag string) ([]Image, string, bool, error) {
+	return r.manifest(ctx, repository, tag, etag)
+}
+
+func (r *httpRemote) manifest(ctx context.Context, repository Repository, tag, ifNoneMatch string) ([]Image, string, bool, error) {
+	accept := fmt.Sprintf("%s, %s", MediaTypeManifestList, MediaTypeOCIImageIndex)
+	resp, notModified, err := r.do(ctx, r.url("/v2/%s/manifests/%s", repository.Path(), tag), accept, ifNoneMatch)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if notModified {
+		return nil, ifNoneMatch, true, nil
+	}
+	defer resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		etag = resp.Header.Get("Docker-Content-Digest")
+	}
+
+	images, err := decodeManifest(resp, repository, tag)
+	return images, etag, false, err
+}
+
+func decodeManifest(resp *http.Response, repository Repository, tag string) ([]Image, error) {
+	switch resp.Header.Get("Content-Type") {
+	case MediaTypeManifestList, MediaTypeOCIImageIndex:
+		var list struct {
+			Manifests []struct {
+				Digest   string `json:"digest"`
+				Platform struct {
+					OS           string `json:"os"`
+					Architecture string `json:"architecture"`
+					Variant      string `json:"variant"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+			return nil, fmt.Errorf("registry: decoding manifest list: %w", err)
+		}
+		images := make([]Image, 0, len(list.Manifests))
+		for _, m := range list.Manifests {
+			images = append(images, Image{
+				Repository: repository,
+				Tag:        tag,
+				Digest:     m.Digest,
+				Platform: Platform{
+					OS:           m.Platform.OS,
+					Architecture: m.Platform.Architecture,
+					Variant:      m.Platform.Variant,
+				},
+			})
+		}
+		return images, nil
+	default:
+		image := Image{
+			Repository: repository,
+			Tag:        tag,
+			Digest:     resp.Header.Get("Docker-Content-Digest"),
+		}
+		return []Image{image}, nil
+	}
+}
+
+func (r *httpRemote) HealthCheck() (Status, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	resp, _, err := r.do(ctx, r.url("/v2/"), "", "")
+	if err != nil {
+		return Status{Healthy: false, Message: err.Error()}, err
+	}
+	resp.Body.Close()
+	return Status{Healthy: true}, nil
+}
+
+// Cancel stops this Remote from starting any further requests. It doesn't
+// abort requests already in flight -- each of those carries its own
+// caller-supplied context, which this Remote has no way to reach back into
+// once the request has started.
+func (r *httpRemote) Cancel() {
+	r.cancel()
+}
@@ -0,0 +1,62 @@
+package registry
+
+import "fmt"
+
+// Platform identifies the OS/architecture an Image was built for. It is
+// the zero value for images resolved from a plain (single-platform)
+// manifest, and populated when the image came out of an OCI image index or
+// Docker manifest list.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// String returns the platform in the conventional "os/arch[/variant]" form,
+// or "" for the zero value.
+func (p Platform) String() string {
+	if p.OS == "" && p.Architecture == "" {
+		return ""
+	}
+	if p.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", p.OS, p.Architecture, p.Variant)
+	}
+	return fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+}
+
+// Image describes a single, resolved image: a repository at a particular
+// tag or digest, along with whatever metadata the registry's manifest gave
+// us. When the tag resolved to a manifest list or OCI image index, Platform
+// identifies which of the index's entries this Image is.
+type Image struct {
+	Repository Repository
+	Tag        string
+	Digest     string
+	Platform   Platform
+}
+
+// String returns "repository:tag" for display purposes, using the
+// repository's requested (non-normalized) form.
+func (i Image) String() string {
+	if i.Tag == "" {
+		return i.Repository.String()
+	}
+	return i.Repository.String() + ":" + i.Tag
+}
+
+// selectPlatform picks the image matching os/arch out of a slice of
+// per-platform images, such as one returned by Remote.Manifest for a
+// manifest list. If only a single image was returned -- the common case of
+// a tag that isn't multi-arch -- it's returned regardless of its Platform,
+// since there's nothing to disambiguate.
+func selectPlatform(images []Image, os, arch string) (Image, error) {
+	if len(images) == 1 {
+		return images[0], nil
+	}
+	for _, img := range images {
+		if img.Platform.OS == os && img.Platform.Architecture == arch {
+			return img, nil
+		}
+	}
+	return Image{}, fmt.Errorf("no manifest found for platform %s/%s", os, arch)
+}
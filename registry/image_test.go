@@ -0,0 +1,41 @@
+package registry
+
+import "testing"
+
+func TestSelectPlatform_SingleImageIgnoresPlatform(t *testing.T) {
+	images := []Image{{Tag: "solo"}}
+
+	got, err := selectPlatform(images, "windows", "arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Tag != "solo" {
+		t.Fatalf("expected the only image regardless of platform, got %v", got)
+	}
+}
+
+func TestSelectPlatform_PicksMatchingEntry(t *testing.T) {
+	images := []Image{
+		{Tag: "multi", Platform: Platform{OS: "linux", Architecture: "arm64"}},
+		{Tag: "multi", Platform: Platform{OS: "linux", Architecture: "amd64"}},
+	}
+
+	got, err := selectPlatform(images, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Platform.Architecture != "amd64" {
+		t.Fatalf("expected amd64 entry, got %+v", got.Platform)
+	}
+}
+
+func TestSelectPlatform_NoMatchIsAnError(t *testing.T) {
+	images := []Image{
+		{Tag: "multi", Platform: Platform{OS: "linux", Architecture: "arm64"}},
+		{Tag: "multi", Platform: Platform{OS: "linux", Architecture: "amd64"}},
+	}
+
+	if _, err := selectPlatform(images, "windows", "amd64"); err == nil {
+		t.Fatal("expected an error when no platform matches")
+	}
+}
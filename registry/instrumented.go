@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics records the behaviour of Remotes, so it can be reported
+// independently of whichever Remote implementation is in use.
+type Metrics interface {
+	ObserveRequestDuration(host, method string, duration time.Duration, success bool)
+}
+
+type instrumentedRemote struct {
+	next    Remote
+	metrics Metrics
+}
+
+// NewInstrumentedRemote wraps a Remote so that every call is timed and
+// reported via the given Metrics.
+func NewInstrumentedRemote(next Remote, metrics Metrics) Remote {
+	return &instrumentedRemote{next: next, metrics: metrics}
+}
+
+func (r *instrumentedRemote) Tags(ctx context.Context, repository Repository) (tags []string, err error) {
+	defer func(begin time.Time) {
+		r.metrics.ObserveRequestDuration(repository.Host(), "Tags", time.Since(begin), err == nil)
+	}(time.Now())
+	return r.next.Tags(ctx, repository)
+}
+
+func (r *instrumentedRemote) Manifest(ctx context.Context, repository Repository, tag string) (images []Image, err error) {
+	defer func(begin time.Time) {
+		r.metrics.ObserveRequestDuration(repository.Host(), "Manifest", time.Since(begin), err == nil)
+	}(time.Now())
+	return r.next.Manifest(ctx, repository, tag)
+}
+
+func (r *instrumentedRemote) HealthCheck() (status Status, err error) {
+	defer func(begin time.Time) {
+		r.metrics.ObserveRequestDuration("", "HealthCheck", time.Since(begin), err == nil)
+	}(time.Now())
+	return r.next.HealthCheck()
+}
+
+func (r *instrumentedRemote) Cancel() {
+	r.next.Cancel()
+}
@@ -0,0 +1,34 @@
+// Package quay is a registry adapter for quay.io. Importing it for its
+// side effect registers the adapter with the registry package; nothing
+// else needs to reference it directly.
+package quay
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/firehalt/flux/registry"
+)
+
+func init() {
+	registry.RegisterAdapter("quay", factory{})
+}
+
+type factory struct{}
+
+func (factory) Matches(host string) bool {
+	return host == "quay.io"
+}
+
+// New builds a Remote authenticated with a Quay robot account, read from
+// the QUAY_ROBOT_USERNAME/QUAY_ROBOT_TOKEN environment variables. Unlike
+// the other cloud adapters, robot account tokens don't expire, so there's
+// no need to wrap this in a registry.RefreshingRemote.
+func (factory) New(host string) (registry.Remote, error) {
+	username := os.Getenv("QUAY_ROBOT_USERNAME")
+	token := os.Getenv("QUAY_ROBOT_TOKEN")
+	if username == "" || token == "" {
+		return nil, fmt.Errorf("quay: QUAY_ROBOT_USERNAME and QUAY_ROBOT_TOKEN must be set")
+	}
+	return registry.NewHTTPRemote(host, username, token)
+}
@@ -2,71 +2,202 @@
 package registry
 
 import (
-	"github.com/go-kit/kit/log"
+	"context"
+	"fmt"
 	"sort"
+	"sync"
 	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/go-kit/kit/log"
 )
 
 const (
 	requestTimeout = 10 * time.Second
+
+	// defaultMaxConcurrentManifests bounds how many manifest requests a
+	// single GetRepository call has in flight at once, used when
+	// RegistryOptions.MaxConcurrentManifests is left at its zero value.
+	defaultMaxConcurrentManifests = 16
 )
 
+// RegistryOptions configures a registry's behaviour across every
+// repository it is asked to fetch.
+type RegistryOptions struct {
+	// MaxConcurrentManifests bounds how many manifest requests GetRepository
+	// has in flight at once for a single repository. Defaults to
+	// defaultMaxConcurrentManifests if zero or negative.
+	MaxConcurrentManifests int
+	// BestEffort makes GetRepository return whatever images it managed to
+	// fetch, wrapped with ErrPartialResults, instead of discarding them
+	// when a manifest fetch fails or the context is cancelled.
+	BestEffort bool
+}
+
+// defaultOS and defaultArch are the platform GetImage and GetImageByDigest
+// pick out of a multi-arch manifest list when the caller hasn't asked for a
+// specific platform via GetImageForPlatform or WithPlatform.
+const (
+	defaultOS   = "linux"
+	defaultArch = "amd64"
+)
+
+// platformFilter narrows a GetRepository call to a single OS/architecture,
+// so that a repository with multi-arch tags yields one Image per tag
+// rather than one per platform.
+type platformFilter struct {
+	os, arch string
+}
+
+// RepositoryOption configures a single GetRepository call.
+type RepositoryOption func(*platformFilter)
+
+// WithPlatform restricts GetRepository to the image matching the given OS
+// and architecture within each tag's manifest (list), instead of returning
+// every platform variant found.
+func WithPlatform(os, arch string) RepositoryOption {
+	return func(f *platformFilter) {
+		f.os, f.arch = os, arch
+	}
+}
+
 // The Registry interface is a domain specific API to access container registries.
 type Registry interface {
-	GetRepository(repository Repository) ([]Image, error)
-	GetImage(repository Repository, tag string) (Image, error)
+	GetRepository(ctx context.Context, repository Repository, opts ...RepositoryOption) ([]Image, error)
+	GetImage(ctx context.Context, repository Repository, tag string) (Image, error)
+	GetImageByDigest(ctx context.Context, repository Repository, digest string) (Image, error)
+	GetImageForPlatform(ctx context.Context, repository Repository, tag, os, arch string) (Image, error)
+	ResolveReference(ref string) (Repository, string, error)
+	// HealthCheck reports whether the registry at host is reachable and
+	// authenticated, dispatching to whichever adapter (if any) owns host.
+	HealthCheck(host string) (Status, error)
 }
 
 type registry struct {
 	factory RemoteClientFactory
 	Logger  log.Logger
 	Metrics Metrics
+	Options RegistryOptions
 }
 
 // NewClient creates a new registry registry, to use when fetching repositories.
 func NewRegistry(c RemoteClientFactory, l log.Logger, m Metrics) Registry {
+	return NewRegistryWithOptions(c, l, m, RegistryOptions{})
+}
+
+// NewRegistryWithOptions is like NewRegistry, but lets the caller tune
+// concurrency and error-handling behaviour via RegistryOptions.
+func NewRegistryWithOptions(c RemoteClientFactory, l log.Logger, m Metrics, opts RegistryOptions) Registry {
 	return &registry{
 		factory: c,
 		Logger:  l,
 		Metrics: m,
+		Options: opts,
 	}
 }
 
 // GetRepository yields a repository matching the given name, if any exists.
-// Repository may be of various forms, in which case omitted elements take
-// assumed defaults.
-//
-//   helloworld             -> index.docker.io/library/helloworld
-//   foo/helloworld         -> index.docker.io/foo/helloworld
-//   quay.io/foo/helloworld -> quay.io/foo/helloworld
-//
-func (reg *registry) GetRepository(img Repository) (_ []Image, err error) {
+// Repository parsing (including defaulting of host and "library/" org) is
+// handled by ParseRepository; this just fetches and resolves its tags.
+func (reg *registry) GetRepository(ctx context.Context, img Repository, opts ...RepositoryOption) (_ []Image, err error) {
 	rem, err := reg.newRemote(img)
 	if err != nil {
 		return
 	}
 
-	tags, err := rem.Tags(img)
+	tags, err := rem.Tags(ctx, img)
 	if err != nil {
 		rem.Cancel()
 		return nil, err
 	}
 
-	// the hostlessImageName is canonicalised, in the sense that it
-	// includes "library" as the org, if unqualified -- e.g.,
-	// `library/nats`. We need that to fetch the tags etc. However, we
-	// want the results to use the *actual* name of the images to be
-	// as supplied, e.g., `nats`.
-	return reg.tagsToRepository(rem, img, tags)
+	var filter *platformFilter
+	if len(opts) > 0 {
+		filter = &platformFilter{}
+		for _, opt := range opts {
+			opt(filter)
+		}
+	}
+
+	return reg.tagsToRepository(ctx, rem, img, tags, filter)
+}
+
+// Get a single Image from the registry if it exists. If the tag resolves to
+// a multi-arch manifest list, the entry for defaultOS/defaultArch is
+// returned; use GetImageForPlatform to pick a different one.
+func (reg *registry) GetImage(ctx context.Context, img Repository, tag string) (_ Image, err error) {
+	return reg.GetImageForPlatform(ctx, img, tag, defaultOS, defaultArch)
 }
 
-// Get a single Image from the registry if it exists
-func (reg *registry) GetImage(img Repository, tag string) (_ Image, err error) {
+// GetImageByDigest fetches the image pinned to the given digest (e.g.
+// "sha256:abcd..."), rather than a mutable tag. This is the counterpart to
+// GetImage for callers that already have a digest-pinned reference, such as
+// one resolved by ResolveReference.
+func (reg *registry) GetImageByDigest(ctx context.Context, img Repository, digest string) (_ Image, err error) {
+	canonical, err := img.WithDigest(digest)
+	if err != nil {
+		return Image{}, err
+	}
 	rem, err := reg.newRemote(img)
 	if err != nil {
 		return
 	}
-	return rem.Manifest(img, tag)
+	images, err := rem.Manifest(ctx, img, canonical.Digest().String())
+	if err != nil {
+		return Image{}, err
+	}
+	return selectPlatform(images, defaultOS, defaultArch)
+}
+
+// GetImageForPlatform fetches the image for tag that matches os/arch,
+// resolving a multi-arch manifest list if the registry returns one.
+func (reg *registry) GetImageForPlatform(ctx context.Context, img Repository, tag, os, arch string) (Image, error) {
+	rem, err := reg.newRemote(img)
+	if err != nil {
+		return Image{}, err
+	}
+	images, err := rem.Manifest(ctx, img, tag)
+	if err != nil {
+		return Image{}, err
+	}
+	return selectPlatform(images, os, arch)
+}
+
+// ResolveReference parses a single reference string -- anything accepted by
+// `docker pull`, including `name@sha256:...` and
+// `registry:port/ns/name:tag` -- into the Repository and tag/digest it
+// names. If ref has no tag, it resolves to "latest" as reference.TagNameOnly
+// would. Callers that need to distinguish a digest from a tag can check
+// whether the returned string has a "sha256:" (or similar) prefix.
+func (reg *registry) ResolveReference(ref string) (Repository, string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return Repository{}, "", err
+	}
+
+	if canonical, ok := named.(reference.Canonical); ok {
+		repo, err := ParseRepository(reference.Domain(named) + "/" + reference.Path(named))
+		return repo, canonical.Digest().String(), err
+	}
+
+	tagged := reference.TagNameOnly(named)
+	repo, err := ParseRepository(reference.Domain(named) + "/" + reference.Path(named))
+	if err != nil {
+		return Repository{}, "", err
+	}
+	return repo, tagged.(reference.Tagged).Tag(), nil
+}
+
+// HealthCheck reports whether host is reachable and authenticated. Unlike
+// the other Registry methods, it isn't scoped to a Repository, so it goes
+// straight to reg.factory rather than through newRemote.
+func (reg *registry) HealthCheck(host string) (Status, error) {
+	rem, err := reg.factory.CreateFor(host)
+	if err != nil {
+		return Status{}, err
+	}
+	defer rem.Cancel()
+	return rem.HealthCheck()
 }
 
 func (reg *registry) newRemote(img Repository) (rem Remote, err error) {
@@ -78,55 +209,114 @@ func (reg *registry) newRemote(img Repository) (rem Remote, err error) {
 	return
 }
 
-func (reg *registry) tagsToRepository(remote Remote, repository Repository, tags []string) ([]Image, error) {
+// tagsToRepository fetches the manifest for each tag using a bounded pool
+// of workers, so that a repository with thousands of tags doesn't open
+// thousands of simultaneous connections to the registry. On the first
+// error it cancels the context so outstanding fetches give up quickly and
+// no further tags are handed out, rather than letting every fetch run to
+// completion.
+func (reg *registry) tagsToRepository(ctx context.Context, remote Remote, repository Repository, tags []string, filter *platformFilter) ([]Image, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	// one way or another, we'll be finishing all requests
 	defer remote.Cancel()
+	defer cancel()
+
+	workers := reg.Options.MaxConcurrentManifests
+	if workers <= 0 {
+		workers = defaultMaxConcurrentManifests
+	}
 
 	type result struct {
-		image Image
-		err   error
+		images []Image
+		err    error
 	}
 
+	tagCh := make(chan string)
 	fetched := make(chan result, len(tags))
 
-	for _, tag := range tags {
-		go func(t string) {
-			image, err := remote.Manifest(repository, t)
-			if err != nil {
-				reg.Logger.Log("registry-metadata-err", err)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range tagCh {
+				images, err := remote.Manifest(ctx, repository, t)
+				if err == nil && filter != nil {
+					var img Image
+					img, err = selectPlatform(images, filter.os, filter.arch)
+					images = []Image{img}
+				}
+				if err != nil {
+					reg.Logger.Log("registry-metadata-err", err)
+				}
+				fetched <- result{images, err}
 			}
-			fetched <- result{image, err}
-		}(tag)
+		}()
 	}
 
-	images := make([]Image, cap(fetched))
-	for i := 0; i < cap(fetched); i++ {
-		res := <-fetched
+	go func() {
+		defer close(tagCh)
+		for _, tag := range tags {
+			select {
+			case tagCh <- tag:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+
+	images := make([]Image, 0, len(tags))
+	var firstErr error
+	received := 0
+	for res := range fetched {
+		received++
 		if res.err != nil {
-			return nil, res.err
+			if firstErr == nil {
+				firstErr = res.err
+				cancel() // stop outstanding fetches rather than draining them
+			}
+			continue
 		}
-		images[i] = res.image
+		images = append(images, res.images...)
 	}
+	if firstErr == nil && received < len(tags) {
+		// the caller's ctx was cancelled before every tag was handed out
+		firstErr = ctx.Err()
+	}
+
+	sort.Sort(byNameAndPlatform(images))
 
-	sort.Sort(byCreatedDesc(images))
-	return images, nil
+	switch {
+	case firstErr == nil:
+		return images, nil
+	case reg.Options.BestEffort:
+		return images, fmt.Errorf("%w: %v", ErrPartialResults, firstErr)
+	default:
+		return nil, firstErr
+	}
 }
 
 // -----
 
-type byCreatedDesc []Image
+// byNameAndPlatform orders images for GetRepository's return value. Image
+// carries no creation time (none of the manifest formats we decode carry
+// one without an extra blob fetch per image), so there's nothing to sort on
+// but the name; it's here mainly to make the order deterministic rather
+// than dependent on worker-pool scheduling.
+type byNameAndPlatform []Image
 
-func (is byCreatedDesc) Len() int      { return len(is) }
-func (is byCreatedDesc) Swap(i, j int) { is[i], is[j] = is[j], is[i] }
-func (is byCreatedDesc) Less(i, j int) bool {
-	if is[i].CreatedAt == nil {
-		return true
-	}
-	if is[j].CreatedAt == nil {
-		return false
-	}
-	if is[i].CreatedAt.Equal(*is[j].CreatedAt) {
+func (is byNameAndPlatform) Len() int      { return len(is) }
+func (is byNameAndPlatform) Swap(i, j int) { is[i], is[j] = is[j], is[i] }
+func (is byNameAndPlatform) Less(i, j int) bool {
+	if is[i].String() != is[j].String() {
 		return is[i].String() < is[j].String()
 	}
-	return is[i].CreatedAt.After(*is[j].CreatedAt)
+	// same repo:tag, e.g. two platforms off the same manifest list --
+	// tiebreak on platform so the order is deterministic.
+	return is[i].Platform.String() < is[j].Platform.String()
 }
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// tagRemote is a fake Remote for exercising tagsToRepository: Manifest
+// looks up tag in images/errs, optionally blocking until ctx is done (or a
+// timeout) so tests can observe cancel-on-first-error behaviour.
+type tagRemote struct {
+	images  map[string]Image
+	errs    map[string]error
+	block   map[string]bool
+	cancels int
+}
+
+func (r *tagRemote) Tags(ctx context.Context, repository Repository) ([]string, error) {
+	panic("not used by these tests")
+}
+
+func (r *tagRemote) Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error) {
+	if r.block[tag] {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return nil, fmt.Errorf("tagRemote: %s was not cancelled in time", tag)
+		}
+	}
+	if err, ok := r.errs[tag]; ok {
+		return nil, err
+	}
+	return []Image{r.images[tag]}, nil
+}
+
+func (r *tagRemote) HealthCheck() (Status, error) { return Status{Healthy: true}, nil }
+func (r *tagRemote) Cancel()                      { r.cancels++ }
+
+func newTestRegistry() *registry {
+	return &registry{Logger: log.NewNopLogger(), Options: RegistryOptions{MaxConcurrentManifests: 2}}
+}
+
+func TestTagsToRepository_AllSucceed(t *testing.T) {
+	rem := &tagRemote{images: map[string]Image{
+		"a": {Tag: "a"},
+		"b": {Tag: "b"},
+	}}
+	reg := newTestRegistry()
+
+	images, err := reg.tagsToRepository(context.Background(), rem, Repository{}, []string{"a", "b"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(images))
+	}
+}
+
+func TestTagsToRepository_ErrorDiscardsResults(t *testing.T) {
+	boom := errors.New("boom")
+	rem := &tagRemote{
+		images: map[string]Image{"a": {Tag: "a"}},
+		errs:   map[string]error{"b": boom},
+	}
+	reg := newTestRegistry()
+
+	images, err := reg.tagsToRepository(context.Background(), rem, Repository{}, []string{"a", "b"}, nil)
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if images != nil {
+		t.Fatalf("expected no images on hard failure, got %v", images)
+	}
+}
+
+func TestTagsToRepository_BestEffortKeepsPartialResults(t *testing.T) {
+	boom := errors.New("boom")
+	rem := &tagRemote{
+		images: map[string]Image{"a": {Tag: "a"}},
+		errs:   map[string]error{"b": boom},
+	}
+	reg := newTestRegistry()
+	reg.Options.BestEffort = true
+
+	images, err := reg.tagsToRepository(context.Background(), rem, Repository{}, []string{"a", "b"}, nil)
+	if !errors.Is(err, ErrPartialResults) {
+		t.Fatalf("expected ErrPartialResults, got %v", err)
+	}
+	if len(images) != 1 || images[0].Tag != "a" {
+		t.Fatalf("expected the one successful image, got %v", images)
+	}
+}
+
+func TestTagsToRepository_CancelsOutstandingFetchesOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	rem := &tagRemote{
+		errs:  map[string]error{"bad": boom},
+		block: map[string]bool{"slow": true},
+	}
+	reg := newTestRegistry()
+
+	start := time.Now()
+	_, err := reg.tagsToRepository(context.Background(), rem, Repository{}, []string{"bad", "slow"}, nil)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	// "slow" blocks for a full second unless its context is cancelled as
+	// soon as "bad" fails; this should come back in well under that.
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("tagsToRepository took %v, want outstanding fetch to be cancelled promptly", elapsed)
+	}
+	if rem.cancels != 1 {
+		t.Fatalf("expected remote.Cancel() to be called once, got %d", rem.cancels)
+	}
+}
@@ -0,0 +1,35 @@
+package registry
+
+import "context"
+
+const (
+	// MediaTypeManifestList is the media type of a Docker multi-arch manifest list.
+	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// MediaTypeOCIImageIndex is the OCI equivalent of MediaTypeManifestList.
+	MediaTypeOCIImageIndex = "application/vnd.oci.image.index.v1+json"
+)
+
+// Remote is the interface implemented by the underlying clients that talk
+// to a specific registry host, e.g. a generic Docker v2 HTTP client, or a
+// cloud-specific adapter. Registry wraps a Remote with metrics
+// (NewInstrumentedRemote), and is otherwise oblivious to how tags and
+// manifests are actually fetched.
+//
+// Implementations must respect ctx cancellation/deadlines on Tags and
+// Manifest, returning promptly with ctx.Err() (or a wrapped form of it)
+// rather than relying solely on the package's requestTimeout.
+type Remote interface {
+	Tags(ctx context.Context, repository Repository) ([]string, error)
+	// Manifest resolves a tag to the Images it names. For an ordinary,
+	// single-platform manifest this is a single-element slice. For a
+	// manifest list or OCI image index (MediaTypeManifestList /
+	// MediaTypeOCIImageIndex), it's one Image per platform entry, each
+	// with Platform populated, rather than one arbitrarily-chosen entry.
+	Manifest(ctx context.Context, repository Repository, tag string) ([]Image, error)
+	// HealthCheck reports whether the remote is currently reachable and
+	// authenticated, without fetching any particular repository. Adapters
+	// typically implement this as a request against the registry's base
+	// "/v2/" endpoint.
+	HealthCheck() (Status, error)
+	Cancel()
+}
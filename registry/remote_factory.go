@@ -0,0 +1,8 @@
+package registry
+
+// RemoteClientFactory creates a Remote suitable for talking to the given
+// registry host. Implementations decide how to authenticate -- e.g., basic
+// auth from a local config, or a cloud-specific token exchange.
+type RemoteClientFactory interface {
+	CreateFor(host string) (Remote, error)
+}
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+)
+
+// Repository names an image repository, e.g., "alpine", "foo/bar", or
+// "quay.io/weaveworks/flux". It wraps a normalized reference.Named so we get
+// Docker's own rules for default registries and implicit "library"
+// namespaces for free, instead of re-deriving them with host/org/name string
+// splitting.
+type Repository struct {
+	named reference.Named
+	// requested is the repository string as the caller supplied it, before
+	// normalization. We keep it around so that results can be reported back
+	// using the name the caller used (e.g. "nats"), rather than the
+	// normalized form used to actually fetch tags (e.g.
+	// "docker.io/library/nats").
+	requested string
+}
+
+// ParseRepository parses a repository name into a Repository, applying the
+// same defaulting rules as `docker pull`:
+//
+//   helloworld             -> docker.io/library/helloworld
+//   foo/helloworld         -> docker.io/foo/helloworld
+//   quay.io/foo/helloworld -> quay.io/foo/helloworld
+//
+func ParseRepository(repo string) (Repository, error) {
+	named, err := reference.ParseNormalizedNamed(repo)
+	if err != nil {
+		return Repository{}, fmt.Errorf("parsing repository %q: %w", repo, err)
+	}
+	return Repository{named: named, requested: repo}, nil
+}
+
+// Host returns the registry host for the repository, e.g. "quay.io" or
+// "docker.io", or "" for a zero-value Repository not built via
+// ParseRepository.
+func (r Repository) Host() string {
+	if r.named == nil {
+		return ""
+	}
+	return reference.Domain(r.named)
+}
+
+// Path returns the namespace/name part of the repository, e.g.
+// "library/alpine", or "" for a zero-value Repository not built via
+// ParseRepository.
+func (r Repository) Path() string {
+	if r.named == nil {
+		return ""
+	}
+	return reference.Path(r.named)
+}
+
+// String returns the repository as the caller originally supplied it, which
+// is what should be shown to users; use NormalizedName if the canonical,
+// library-qualified form is needed instead.
+func (r Repository) String() string {
+	if r.requested != "" {
+		return r.requested
+	}
+	if r.named == nil {
+		return ""
+	}
+	return r.named.Name()
+}
+
+// NormalizedName returns the canonical, normalized name of the repository,
+// e.g. "docker.io/library/alpine" rather than "alpine", or "" for a zero-value
+// Repository not built via ParseRepository.
+func (r Repository) NormalizedName() string {
+	if r.named == nil {
+		return ""
+	}
+	return r.named.Name()
+}
+
+// WithTag qualifies the repository with a tag, returning a reference
+// suitable for passing to a Remote.
+func (r Repository) WithTag(tag string) (reference.NamedTagged, error) {
+	if r.named == nil {
+		return nil, fmt.Errorf("registry: zero-value Repository (want one built via ParseRepository)")
+	}
+	return reference.WithTag(r.named, tag)
+}
+
+// WithDigest qualifies the repository with a digest, returning a canonical
+// reference suitable for passing to a Remote.
+func (r Repository) WithDigest(digest string) (reference.Canonical, error) {
+	if r.named == nil {
+		return nil, fmt.Errorf("registry: zero-value Repository (want one built via ParseRepository)")
+	}
+	d, err := parseDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	return reference.WithDigest(r.named, d)
+}
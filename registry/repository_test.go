@@ -0,0 +1,100 @@
+package registry
+
+import "testing"
+
+func TestParseRepository(t *testing.T) {
+	for _, tc := range []struct {
+		name, host, path, normalized string
+	}{
+		{"helloworld", "docker.io", "library/helloworld", "docker.io/library/helloworld"},
+		{"foo/helloworld", "docker.io", "foo/helloworld", "docker.io/foo/helloworld"},
+		{"quay.io/foo/helloworld", "quay.io", "foo/helloworld", "quay.io/foo/helloworld"},
+		{"localhost:5000/foo/bar", "localhost:5000", "foo/bar", "localhost:5000/foo/bar"},
+	} {
+		repo, err := ParseRepository(tc.name)
+		if err != nil {
+			t.Fatalf("ParseRepository(%q): unexpected error: %v", tc.name, err)
+		}
+		if got := repo.Host(); got != tc.host {
+			t.Errorf("ParseRepository(%q).Host() = %q, want %q", tc.name, got, tc.host)
+		}
+		if got := repo.Path(); got != tc.path {
+			t.Errorf("ParseRepository(%q).Path() = %q, want %q", tc.name, got, tc.path)
+		}
+		if got := repo.NormalizedName(); got != tc.normalized {
+			t.Errorf("ParseRepository(%q).NormalizedName() = %q, want %q", tc.name, got, tc.normalized)
+		}
+		if got := repo.String(); got != tc.name {
+			t.Errorf("ParseRepository(%q).String() = %q, want the requested name back", tc.name, got)
+		}
+	}
+}
+
+func TestParseRepository_Invalid(t *testing.T) {
+	if _, err := ParseRepository("UPPERCASE/not/allowed"); err == nil {
+		t.Fatal("expected an error for an invalid repository name")
+	}
+}
+
+// TestRepository_ZeroValue guards against a Repository that escapes without
+// going through ParseRepository (e.g. a struct literal, or a failed parse
+// whose zero Repository return value was used anyway): its accessors
+// should report absence, not panic on the nil reference.Named.
+func TestRepository_ZeroValue(t *testing.T) {
+	var repo Repository
+
+	if got := repo.Host(); got != "" {
+		t.Errorf("Host() = %q, want \"\"", got)
+	}
+	if got := repo.Path(); got != "" {
+		t.Errorf("Path() = %q, want \"\"", got)
+	}
+	if got := repo.NormalizedName(); got != "" {
+		t.Errorf("NormalizedName() = %q, want \"\"", got)
+	}
+	if got := repo.String(); got != "" {
+		t.Errorf("String() = %q, want \"\"", got)
+	}
+	if _, err := repo.WithTag("latest"); err == nil {
+		t.Error("WithTag() on a zero-value Repository: expected an error, got nil")
+	}
+	if _, err := repo.WithDigest("sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"); err == nil {
+		t.Error("WithDigest() on a zero-value Repository: expected an error, got nil")
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	reg := newTestRegistry()
+
+	for _, tc := range []struct {
+		ref, wantRepo, wantTagOrDigest string
+	}{
+		{"nats", "docker.io/library/nats", "latest"},
+		{"nats:1.2.3", "docker.io/library/nats", "1.2.3"},
+		{"quay.io/weaveworks/flux:1.2.3", "quay.io/weaveworks/flux", "1.2.3"},
+		{"localhost:5000/foo/bar:1.2.3", "localhost:5000/foo/bar", "1.2.3"},
+		{
+			"quay.io/weaveworks/flux@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			"quay.io/weaveworks/flux",
+			"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	} {
+		repo, tagOrDigest, err := reg.ResolveReference(tc.ref)
+		if err != nil {
+			t.Fatalf("ResolveReference(%q): unexpected error: %v", tc.ref, err)
+		}
+		if got := repo.NormalizedName(); got != tc.wantRepo {
+			t.Errorf("ResolveReference(%q) repository = %q, want %q", tc.ref, got, tc.wantRepo)
+		}
+		if tagOrDigest != tc.wantTagOrDigest {
+			t.Errorf("ResolveReference(%q) tag/digest = %q, want %q", tc.ref, tagOrDigest, tc.wantTagOrDigest)
+		}
+	}
+}
+
+func TestResolveReference_Invalid(t *testing.T) {
+	reg := newTestRegistry()
+	if _, _, err := reg.ResolveReference("UPPERCASE/not/allowed"); err == nil {
+		t.Fatal("expected an error for an invalid reference")
+	}
+}